@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/chandiwalaaadhar/test/rsimg"
+)
+
+// encodeDataToImage JSON-encodes data and erasure-codes it into an image
+// using the default EncoderOptions.
+func encodeDataToImage(data interface{}) (image.Image, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := rsimg.NewEncoder(rsimg.DefaultEncoderOptions())
+	if err != nil {
+		return nil, err
+	}
+	return enc.Encode(dataBytes)
+}
+
+// decodeImageToData reverses encodeDataToImage, reconstructing the
+// erasure-coded payload and JSON-decoding it back into a map.
+func decodeImageToData(img image.Image) (map[string]interface{}, error) {
+	rebuiltData, err := rsimg.NewDecoder().Decode(img)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rebuiltData, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func readImageFromPath(filePath string) image.Image {
+	file, err := os.Open(filePath)
+	if err != nil {
+		fmt.Println("Error opening image file:", err)
+		return nil
+	}
+	defer file.Close()
+
+	// image.Decode picks the right codec on its own: rsimg registers
+	// itself on init, so this works the same for any file this program
+	// wrote regardless of which container it picked.
+	img, _, err := image.Decode(file)
+	if err != nil {
+		fmt.Println("Error decoding image:", err)
+		return nil
+	}
+	return img
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage:")
+		fmt.Println("Encode: go run . encode \"Your text here\" [filename]")
+		fmt.Println("Decode: go run . decode filename.png")
+		return
+	}
+
+	action := os.Args[1]
+	filename := "output.png"
+
+	switch action {
+	case "encode":
+		text := os.Args[2]
+		if len(os.Args) > 3 {
+			filename = os.Args[3]
+			// if filename does not already end in .png, add .png
+			if len(filename) < 4 || filename[len(filename)-4:] != ".png" {
+				filename += ".png"
+			}
+		}
+
+		data := map[string]interface{}{
+			"Data": text,
+		}
+
+		// Encode data to image
+		img, err := encodeDataToImage(data)
+		if err != nil {
+			fmt.Println("Error encoding data:", err)
+			return
+		}
+
+		// Save image wrapped in the rsimg envelope, using PNG as the
+		// container.
+		file, _ := os.Create(filename)
+		err = rsimg.WriteFile(file, img, rsimg.ContainerPNG)
+
+		if err != nil {
+			fmt.Println("Error saving as png:", err)
+			return
+		}
+
+		err = file.Close()
+		if err != nil {
+			fmt.Println("Error closing file:", err)
+			return
+		}
+
+	case "decode":
+		filename = os.Args[2]
+
+		// Decode data from image
+		decodedData, err := decodeImageToData(readImageFromPath(filename))
+		if err != nil {
+			fmt.Println("Error decoding data:", err)
+			return
+		}
+
+		fmt.Println("Decoded data:", decodedData["Data"])
+
+	default:
+		fmt.Println("Invalid action. Use 'encode' or 'decode'.")
+	}
+}
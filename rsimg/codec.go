@@ -0,0 +1,43 @@
+package rsimg
+
+import "encoding/json"
+
+// Codec marshals and unmarshals values for Encoder.EncodeValue and
+// Decoder.DecodeInto. Register additional codecs - msgpack, CBOR,
+// protobuf - with RegisterCodec; CBOR in particular packs binary payloads
+// like embedded images or DICOM metadata far more densely than JSON.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Built-in codec IDs. CodecRaw is reserved for Encoder.Encode, which
+// stores plaintext bytes with no associated codec; DecodeInto rejects it,
+// since there is nothing registered to unmarshal with.
+const (
+	CodecRaw  byte = 0
+	CodecJSON byte = 1
+)
+
+var codecs = map[byte]Codec{}
+
+// RegisterCodec makes c available under id for Encoder.EncodeValue and
+// Decoder.DecodeInto. Registering under an id that's already in use
+// replaces the previous codec.
+func RegisterCodec(id byte, c Codec) {
+	codecs[id] = c
+}
+
+func init() {
+	RegisterCodec(CodecJSON, jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
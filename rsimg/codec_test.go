@@ -0,0 +1,45 @@
+package rsimg
+
+import "testing"
+
+type greeting struct {
+	Name string
+	Text string
+}
+
+func TestEncodeValueDecodeInto(t *testing.T) {
+	enc, err := NewEncoder(DefaultEncoderOptions())
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	want := greeting{Name: "Ada", Text: "hello"}
+	img, err := enc.EncodeValue(want, CodecJSON)
+	if err != nil {
+		t.Fatalf("EncodeValue: %v", err)
+	}
+
+	var got greeting
+	if err := NewDecoder().DecodeInto(img, &got); err != nil {
+		t.Fatalf("DecodeInto: %v", err)
+	}
+	if got != want {
+		t.Fatalf("DecodeInto = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeIntoRejectsRawImages(t *testing.T) {
+	enc, err := NewEncoder(DefaultEncoderOptions())
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	img, err := enc.Encode([]byte("no codec here"))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var v map[string]interface{}
+	if err := NewDecoder().DecodeInto(img, &v); err == nil {
+		t.Fatalf("expected DecodeInto to fail for an image encoded with Encode, not EncodeValue")
+	}
+}
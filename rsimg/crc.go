@@ -0,0 +1,42 @@
+package rsimg
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// crcBlockSize returns the number of bytes a stripe's CRC32 block
+// occupies: one 4-byte checksum per shard, written immediately before
+// that stripe's shard data. This mirrors how PNG itself keeps a CRC32
+// alongside every chunk, verified before the decoder trusts the chunk's
+// contents.
+func crcBlockSize(totalShards int) int {
+	return totalShards * 4
+}
+
+// shardCRCs computes a CRC32 checksum for each shard, in canonical
+// (pre-interleave) order.
+func shardCRCs(shards [][]byte) []byte {
+	block := make([]byte, 0, len(shards)*4)
+	for _, s := range shards {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], crc32.ChecksumIEEE(s))
+		block = append(block, b[:]...)
+	}
+	return block
+}
+
+// verifyShardCRCs checks each shard against the checksum recorded for it
+// in block, nils out any shard whose checksum doesn't match so
+// Reconstruct repairs it from parity instead of trusting corrupted data,
+// and reports how many shards were found corrupted.
+func verifyShardCRCs(shards [][]byte, block []byte) (corrupted int) {
+	for i, s := range shards {
+		want := binary.BigEndian.Uint32(block[i*4 : i*4+4])
+		if crc32.ChecksumIEEE(s) != want {
+			shards[i] = nil
+			corrupted++
+		}
+	}
+	return corrupted
+}
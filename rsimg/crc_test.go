@@ -0,0 +1,84 @@
+package rsimg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// corrupt flips a byte within n payload bytes starting at byteOffset,
+// unlike blank it never zeroes a whole shard, so the old all-zero-shard
+// heuristic would have missed it entirely.
+func corrupt(img *image.NRGBA, byteOffset, n int) {
+	width := img.Bounds().Max.X
+	idx := byteOffset + n/2
+	x := idx % width
+	y := idx / width
+	r, _, _, _ := img.At(x, y).RGBA()
+	img.Set(x, y, color.Gray{Y: byte(r>>8) ^ 0xFF})
+}
+
+func TestDecodeWithStatsDetectsCorruptionWithinParityBudget(t *testing.T) {
+	opts := EncoderOptions{DataShards: 4, ParityShards: 2, MaxShardSize: 256}
+	enc, err := NewEncoder(opts)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	payload := make([]byte, 900)
+	rand.New(rand.NewSource(3)).Read(payload)
+
+	img, err := enc.Encode(payload)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("expected *image.NRGBA, got %T", img)
+	}
+
+	// Flip one byte within shard 0's data. The old all-zero heuristic
+	// would never have noticed this; the CRC check must.
+	totalShards := opts.DataShards + opts.ParityShards
+	shardStart := headerSize + crcBlockSize(totalShards)
+	corrupt(nrgba, shardStart, opts.MaxShardSize)
+
+	got, stats, err := NewDecoder().DecodeWithStats(nrgba)
+	if err != nil {
+		t.Fatalf("DecodeWithStats: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("decoded data does not match original after single-byte corruption")
+	}
+	if stats.CorruptedShards != 1 {
+		t.Fatalf("CorruptedShards = %d, want 1", stats.CorruptedShards)
+	}
+	if stats.ReconstructedShards != 1 {
+		t.Fatalf("ReconstructedShards = %d, want 1", stats.ReconstructedShards)
+	}
+}
+
+func TestDecodeWithStatsCleanImageReportsNoCorruption(t *testing.T) {
+	enc, err := NewEncoder(DefaultEncoderOptions())
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	payload := []byte("no corruption here")
+	img, err := enc.Encode(payload)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	got, stats, err := NewDecoder().DecodeWithStats(img)
+	if err != nil {
+		t.Fatalf("DecodeWithStats: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("decoded data does not match original")
+	}
+	if stats.CorruptedShards != 0 || stats.ReconstructedShards != 0 {
+		t.Fatalf("stats = %+v, want zero value", stats)
+	}
+}
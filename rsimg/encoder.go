@@ -0,0 +1,384 @@
+package rsimg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// magic identifies the pixel stream as data produced by this package. It
+// doubles as a format version: a future incompatible header layout bumps
+// the trailing byte, the same way formatMagic does for the file envelope.
+var magic = [6]byte{'R', 'S', 'I', 'M', 'G', 0x01}
+
+// headerSize is the number of bytes the header occupies at the start of
+// the pixel stream, before any shard data: magic(6) + dataShards(1) +
+// parityShards(1) + flags(1) + pixelFormat(1) + codecID(1) + origLen(4) +
+// shardSize(4) + numStripes(4).
+const headerSize = 6 + 1 + 1 + 1 + 1 + 1 + 4 + 4 + 4
+
+// EncoderOptions controls the erasure-coding parameters and pixel layout
+// used by Encoder and Decoder.
+type EncoderOptions struct {
+	// DataShards and ParityShards are passed straight through to
+	// reedsolomon.New. Decoding can tolerate losing up to ParityShards
+	// shards per stripe.
+	DataShards   int
+	ParityShards int
+
+	// ShardInterleave selects how shard bytes are arranged in the pixel
+	// stream. When false (the default), shards are written one after
+	// another within a stripe ("stripe-major"), so a localized run of
+	// corrupted pixels tends to destroy whole shards rather than
+	// touching many of them a little. When true, shard bytes are
+	// interleaved column-major within each stripe, spreading any
+	// localized corruption evenly across all of a stripe's shards.
+	ShardInterleave bool
+
+	// MaxShardSize is the largest number of bytes a single data shard
+	// may hold. Payloads larger than DataShards*MaxShardSize are split
+	// into multiple RS stripes, each encoded independently, the same
+	// way Minio's XL layer splits large objects into erasure sets.
+	MaxShardSize int
+
+	// PixelFormat selects how many payload bytes are packed per pixel.
+	// The zero value, PixelGray8, matches the original implementation.
+	PixelFormat PixelFormat
+}
+
+// DefaultEncoderOptions returns the parameters the original implementation
+// used (4 data shards, 2 parity shards, stripe-major layout), with a
+// MaxShardSize large enough that small payloads fit in a single stripe.
+func DefaultEncoderOptions() EncoderOptions {
+	return EncoderOptions{
+		DataShards:      4,
+		ParityShards:    2,
+		ShardInterleave: false,
+		MaxShardSize:    1 << 16, // 64 KiB per shard, per stripe
+		PixelFormat:     PixelGray8,
+	}
+}
+
+// Encoder turns arbitrary byte payloads into images using Reed-Solomon
+// erasure coding, following the EncoderOptions it was constructed with.
+type Encoder struct {
+	opts EncoderOptions
+	rs   reedsolomon.Encoder
+}
+
+// NewEncoder validates opts and constructs an Encoder.
+func NewEncoder(opts EncoderOptions) (*Encoder, error) {
+	if opts.DataShards <= 0 || opts.ParityShards < 0 {
+		return nil, errors.New("rsimg: DataShards must be positive and ParityShards non-negative")
+	}
+	if opts.MaxShardSize <= 0 {
+		return nil, errors.New("rsimg: MaxShardSize must be positive")
+	}
+	rs, err := reedsolomon.New(opts.DataShards, opts.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{opts: opts, rs: rs}, nil
+}
+
+// Encode erasure-codes data into one or more RS stripes and renders the
+// result as an image packed per opts.PixelFormat, with a small header describing the layout
+// prepended to the pixel stream so Decoder doesn't need out-of-band
+// knowledge of how the image was produced.
+func (e *Encoder) Encode(data []byte) (image.Image, error) {
+	payload, err := e.buildPayload(data, CodecRaw)
+	if err != nil {
+		return nil, err
+	}
+	return pixelizeWithHeader(payload[:headerSize], payload[headerSize:], e.opts.PixelFormat), nil
+}
+
+// EncodeValue marshals v with the codec registered under codecID (see
+// RegisterCodec) and erasure-codes the result into an image, recording
+// codecID in the header so Decoder.DecodeInto knows which codec to
+// unmarshal with.
+func (e *Encoder) EncodeValue(v interface{}, codecID byte) (image.Image, error) {
+	codec, ok := codecs[codecID]
+	if !ok {
+		return nil, fmt.Errorf("rsimg: no codec registered for id %d", codecID)
+	}
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := e.buildPayload(data, codecID)
+	if err != nil {
+		return nil, err
+	}
+	return pixelizeWithHeader(payload[:headerSize], payload[headerSize:], e.opts.PixelFormat), nil
+}
+
+// stripeDataSize is the number of plaintext bytes a single stripe can
+// carry before erasure coding.
+func (e *Encoder) stripeDataSize() int {
+	return e.opts.DataShards * e.opts.MaxShardSize
+}
+
+// buildPayload splits data into stripes, erasure-codes each one, lays out
+// the resulting shards according to opts.ShardInterleave, and prepends the
+// header.
+func (e *Encoder) buildPayload(data []byte, codecID byte) ([]byte, error) {
+	stripeData := e.stripeDataSize()
+	numStripes := 1
+	if len(data) > 0 {
+		numStripes = (len(data) + stripeData - 1) / stripeData
+	}
+
+	totalShards := e.opts.DataShards + e.opts.ParityShards
+	shardBytes := e.opts.MaxShardSize
+	stripeBytes := crcBlockSize(totalShards) + totalShards*shardBytes
+
+	out := make([]byte, headerSize, headerSize+numStripes*stripeBytes)
+	putHeader(out[:headerSize], e.opts, codecID, uint32(len(data)), uint32(numStripes))
+
+	for i := 0; i < numStripes; i++ {
+		start := i * stripeData
+		end := start + stripeData
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := make([]byte, stripeData) // zero-padded on the last, short stripe
+		copy(chunk, data[start:end])
+
+		shards := make([][]byte, totalShards)
+		for s := 0; s < e.opts.DataShards; s++ {
+			shards[s] = chunk[s*shardBytes : (s+1)*shardBytes]
+		}
+		for s := e.opts.DataShards; s < totalShards; s++ {
+			shards[s] = make([]byte, shardBytes)
+		}
+		if err := e.rs.Encode(shards); err != nil {
+			return nil, fmt.Errorf("rsimg: encoding stripe %d: %w", i, err)
+		}
+
+		out = append(out, shardCRCs(shards)...)
+		out = append(out, layoutStripe(shards, e.opts.ShardInterleave)...)
+	}
+	return out, nil
+}
+
+// Decoder reconstructs the byte payload erasure-coded into an image by
+// Encoder. Unlike Encoder, it needs no options: everything it needs is
+// read back out of the header.
+type Decoder struct{}
+
+// NewDecoder returns a Decoder.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Decode extracts the pixel stream from img, verifies the header, and
+// reconstructs each stripe, returning the original plaintext bytes.
+func (d *Decoder) Decode(img image.Image) ([]byte, error) {
+	_, data, _, err := d.decode(img)
+	return data, err
+}
+
+// DecodeInto reconstructs the plaintext bytes erasure-coded into img, the
+// same as Decode, then unmarshals them into v using the codec recorded in
+// the header (see RegisterCodec). It returns an error if img was produced
+// by Encoder.Encode rather than Encoder.EncodeValue, since no codec was
+// recorded for it.
+func (d *Decoder) DecodeInto(img image.Image, v interface{}) error {
+	h, data, _, err := d.decode(img)
+	if err != nil {
+		return err
+	}
+	codec, ok := codecs[h.codecID]
+	if !ok {
+		return fmt.Errorf("rsimg: no codec registered for id %d", h.codecID)
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// DecodeStats reports how much silent shard corruption
+// Decoder.DecodeWithStats found and repaired, so callers can log data-integrity events rather
+// than just getting clean output back with no visibility into it.
+type DecodeStats struct {
+	CorruptedShards     int
+	ReconstructedShards int
+}
+
+// DecodeWithStats is Decode plus a DecodeStats describing how many shards
+// failed their CRC32 check and had to be reconstructed from parity.
+func (d *Decoder) DecodeWithStats(img image.Image) ([]byte, DecodeStats, error) {
+	_, data, stats, err := d.decode(img)
+	return data, stats, err
+}
+
+// decode is the shared implementation behind Decode, DecodeInto, and
+// DecodeWithStats.
+func (d *Decoder) decode(img image.Image) (header, []byte, DecodeStats, error) {
+	h, err := parseHeader(extractHeaderBytes(img, headerSize))
+	if err != nil {
+		return header{}, nil, DecodeStats{}, err
+	}
+	rs, err := reedsolomon.New(h.dataShards, h.parityShards)
+	if err != nil {
+		return header{}, nil, DecodeStats{}, err
+	}
+
+	totalShards := h.dataShards + h.parityShards
+	crcLen := crcBlockSize(totalShards)
+	bodyBytes, err := requiredBodyBytes(img, headerSize, h.pixelFormat, crcLen, totalShards, h.shardSize, h.numStripes)
+	if err != nil {
+		return header{}, nil, DecodeStats{}, err
+	}
+	stripeBytes := crcLen + totalShards*int(h.shardSize)
+	body := extractBody(img, headerSize, h.pixelFormat, bodyBytes)
+
+	var stats DecodeStats
+	out := make([]byte, 0, int(h.origLen))
+	for i := uint32(0); i < h.numStripes; i++ {
+		start := int(i) * stripeBytes
+		end := start + stripeBytes
+		if end > len(body) {
+			return header{}, nil, DecodeStats{}, errors.New("rsimg: image is missing stripe data")
+		}
+		crcBlock := body[start : start+crcLen]
+		shards := deinterleaveStripe(body[start+crcLen:end], totalShards, h.interleave)
+
+		corrupted := verifyShardCRCs(shards, crcBlock)
+		stats.CorruptedShards += corrupted
+		if err := rs.Reconstruct(shards); err != nil {
+			return header{}, nil, DecodeStats{}, fmt.Errorf("rsimg: reconstructing stripe %d: %w", i, err)
+		}
+		if corrupted > 0 {
+			stats.ReconstructedShards += corrupted
+		}
+		for s := 0; s < h.dataShards; s++ {
+			out = append(out, shards[s]...)
+		}
+	}
+	if int(h.origLen) > len(out) {
+		return header{}, nil, DecodeStats{}, errors.New("rsimg: data in image is shorter than expected")
+	}
+	return h, out[:h.origLen], stats, nil
+}
+
+// requiredBodyBytes validates that numStripes stripes of
+// crcLen+totalShards*shardSize bytes each actually fit in the pixels img
+// has left after its header, and returns that byte count.
+//
+// shardSize and numStripes come straight out of the header, which is
+// attacker-controlled input to anyone who decodes an untrusted image (and
+// format.go's image.RegisterFormat means that can happen via a plain
+// image.Decode call, not just this package's own API). Without this
+// check, a forged header claiming an enormous numStripes/shardSize would
+// make extractBody loop as many times as the header says: img.At simply
+// returns the zero color past the real bounds, so nothing stops it from
+// allocating gigabytes of zero-filled "body" for a few-hundred-byte image.
+func requiredBodyBytes(img image.Image, headerPixels int, format PixelFormat, crcLen, totalShards int, shardSize, numStripes uint32) (int, error) {
+	bounds := img.Bounds()
+	totalPixels := bounds.Dx() * bounds.Dy()
+	if totalPixels < headerPixels {
+		return 0, errors.New("rsimg: image too small to hold header")
+	}
+	available := uint64(totalPixels-headerPixels) * uint64(format.bytesPerPixel())
+
+	stripeBytes := uint64(crcLen) + uint64(totalShards)*uint64(shardSize)
+	required := uint64(numStripes) * stripeBytes
+	if required > available {
+		return 0, fmt.Errorf("rsimg: header claims %d stripe bytes but the image only holds %d", required, available)
+	}
+	return int(required), nil
+}
+
+// header is the parsed form of the bytes Encoder prepends to the pixel
+// stream.
+type header struct {
+	dataShards   int
+	parityShards int
+	interleave   bool
+	pixelFormat  PixelFormat
+	codecID      byte
+	origLen      uint32
+	shardSize    uint32
+	numStripes   uint32
+}
+
+func putHeader(buf []byte, opts EncoderOptions, codecID byte, origLen, numStripes uint32) {
+	copy(buf[0:6], magic[:])
+	buf[6] = byte(opts.DataShards)
+	buf[7] = byte(opts.ParityShards)
+	buf[8] = 0
+	if opts.ShardInterleave {
+		buf[8] = 1
+	}
+	buf[9] = byte(opts.PixelFormat)
+	buf[10] = codecID
+	binary.BigEndian.PutUint32(buf[11:15], origLen)
+	binary.BigEndian.PutUint32(buf[15:19], uint32(opts.MaxShardSize))
+	binary.BigEndian.PutUint32(buf[19:23], numStripes)
+}
+
+func parseHeader(buf []byte) (header, error) {
+	var h header
+	if len(buf) < headerSize {
+		return h, errors.New("rsimg: insufficient data for header")
+	}
+	for i, m := range magic {
+		if buf[i] != m {
+			return h, errors.New("rsimg: bad magic, not an rsimg image")
+		}
+	}
+	h.dataShards = int(buf[6])
+	h.parityShards = int(buf[7])
+	h.interleave = buf[8] != 0
+	h.pixelFormat = PixelFormat(buf[9])
+	h.codecID = buf[10]
+	h.origLen = binary.BigEndian.Uint32(buf[11:15])
+	h.shardSize = binary.BigEndian.Uint32(buf[15:19])
+	h.numStripes = binary.BigEndian.Uint32(buf[19:23])
+	return h, nil
+}
+
+// layoutStripe concatenates a stripe's shards into the bytes that get
+// written to the pixel stream, either stripe-major (shards back to back)
+// or interleaved column-major (shard bytes round-robined).
+func layoutStripe(shards [][]byte, interleave bool) []byte {
+	if !interleave {
+		buf := make([]byte, 0, len(shards)*len(shards[0]))
+		for _, s := range shards {
+			buf = append(buf, s...)
+		}
+		return buf
+	}
+	shardLen := len(shards[0])
+	buf := make([]byte, len(shards)*shardLen)
+	for b := 0; b < shardLen; b++ {
+		for s, shard := range shards {
+			buf[b*len(shards)+s] = shard[b]
+		}
+	}
+	return buf
+}
+
+// deinterleaveStripe is the inverse of layoutStripe.
+func deinterleaveStripe(buf []byte, numShards int, interleave bool) [][]byte {
+	shardLen := len(buf) / numShards
+	shards := make([][]byte, numShards)
+	if !interleave {
+		for s := 0; s < numShards; s++ {
+			shards[s] = buf[s*shardLen : (s+1)*shardLen]
+		}
+		return shards
+	}
+	for s := 0; s < numShards; s++ {
+		shards[s] = make([]byte, shardLen)
+	}
+	for b := 0; b < shardLen; b++ {
+		for s := 0; s < numShards; s++ {
+			shards[s][b] = buf[b*numShards+s]
+		}
+	}
+	return shards
+}
@@ -0,0 +1,101 @@
+package rsimg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// blank zeros n consecutive payload bytes starting at byteOffset by
+// writing into the pixels that store them.
+func blank(img *image.NRGBA, byteOffset, n int) {
+	width := img.Bounds().Max.X
+	for i := 0; i < n; i++ {
+		idx := byteOffset + i
+		x := idx % width
+		y := idx / width
+		img.Set(x, y, color.Gray{Y: 0})
+	}
+}
+
+func TestEncoderReconstructsWithinParityBudget(t *testing.T) {
+	opts := EncoderOptions{DataShards: 4, ParityShards: 2, MaxShardSize: 256}
+	enc, err := NewEncoder(opts)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	payload := make([]byte, 900)
+	rand.New(rand.NewSource(1)).Read(payload)
+
+	img, err := enc.Encode(payload)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("expected *image.NRGBA, got %T", img)
+	}
+
+	// Blanking a single shard's worth of pixels stays within the
+	// 2-parity-shard budget, so decoding should still recover the data.
+	// The offset skips the stripe's CRC block, which immediately follows
+	// the header, to land on shard 0's actual data.
+	shardStart := headerSize + crcBlockSize(opts.DataShards+opts.ParityShards)
+	blank(nrgba, shardStart, opts.MaxShardSize)
+	got, err := NewDecoder().Decode(nrgba)
+	if err != nil {
+		t.Fatalf("Decode after single-shard blank: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("decoded data does not match original after single-shard blank")
+	}
+}
+
+// TestDecodeRejectsForgedHeaderClaimingMoreDataThanImageHolds guards
+// against a forged numStripes/shardSize driving decode into allocating
+// body bytes far beyond what the image actually contains - decode must
+// reject the header instead of trusting it.
+func TestDecodeRejectsForgedHeaderClaimingMoreDataThanImageHolds(t *testing.T) {
+	opts := EncoderOptions{DataShards: 1, ParityShards: 0, MaxShardSize: 1}
+	hdr := make([]byte, headerSize)
+	putHeader(hdr, opts, CodecRaw, 1, 0xFFFFFFFF)
+	binary.BigEndian.PutUint32(hdr[15:19], 0xFFFFFFFF) // forge shardSize too
+
+	img := pixelizeWithHeader(hdr, []byte{0}, PixelGray8)
+	if _, err := NewDecoder().Decode(img); err == nil {
+		t.Fatalf("expected forged numStripes/shardSize to be rejected, got nil error")
+	}
+}
+
+func TestEncoderFailsBeyondParityBudget(t *testing.T) {
+	opts := EncoderOptions{DataShards: 4, ParityShards: 2, MaxShardSize: 256}
+	enc, err := NewEncoder(opts)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+
+	payload := make([]byte, 900)
+	rand.New(rand.NewSource(2)).Read(payload)
+
+	img, err := enc.Encode(payload)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	nrgba := img.(*image.NRGBA)
+
+	// Blanking three shards' worth of pixels exceeds the 2-parity-shard
+	// budget: reconstruction must either fail outright or return the
+	// wrong bytes, never silently succeed. The offset skips the stripe's
+	// CRC block, which immediately follows the header, to land on shard
+	// 0's actual data.
+	shardStart := headerSize + crcBlockSize(opts.DataShards+opts.ParityShards)
+	blank(nrgba, shardStart, 3*opts.MaxShardSize)
+	got, err := NewDecoder().Decode(nrgba)
+	if err == nil && bytes.Equal(got, payload) {
+		t.Fatalf("expected reconstruction to fail when corruption exceeds the parity budget")
+	}
+}
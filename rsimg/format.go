@@ -0,0 +1,167 @@
+package rsimg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// formatMagic is the 6-byte signature written at the very start of every
+// file produced by WriteFile, ahead of the chosen container's own magic
+// bytes, so image.RegisterFormat (and plain image.Decode) can recognize an
+// rsimg file without any out-of-band information about which container it
+// picked.
+var formatMagic = [6]byte{'R', 'S', 'I', 'M', 'G', 0x01}
+
+func init() {
+	image.RegisterFormat("rsimg", string(formatMagic[:]), Decode, DecodeConfig)
+}
+
+// Container selects which ordinary image codec the RS-coded pixel grid is
+// wrapped in on disk. ContainerPNG (the original choice) is the most
+// broadly compatible; ContainerBMP is uncompressed and fastest to write
+// for ephemeral use; ContainerTIFF tolerates far larger dimensions than
+// either.
+type Container byte
+
+const (
+	ContainerPNG Container = iota
+	ContainerBMP
+	ContainerTIFF
+)
+
+// WriteFile writes img (as produced by Encoder.Encode) to w, wrapped in
+// the rsimg envelope: formatMagic, a container-codec byte, and then img
+// encoded with that container's ordinary codec.
+func WriteFile(w io.Writer, img image.Image, container Container) error {
+	if _, err := w.Write(formatMagic[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{byte(container)}); err != nil {
+		return err
+	}
+	switch container {
+	case ContainerPNG:
+		return png.Encode(w, img)
+	case ContainerBMP:
+		return bmp.Encode(w, img)
+	case ContainerTIFF:
+		return tiff.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("rsimg: unknown container %d", container)
+	}
+}
+
+// readerOnly hides any io.ReaderAt (or other interface) that the wrapped
+// reader happens to implement, exposing nothing but io.Reader.
+//
+// x/image/tiff opportunistically type-asserts its input to io.ReaderAt and,
+// if it succeeds, issues ReadAt calls relative to the start of the whole
+// underlying stream - not relative to how many bytes have already been
+// Read from it. Handing such a reader back from readEnvelope post-prefix
+// would make the TIFF decoder seek straight through the envelope bytes it
+// was never meant to see. Wrapping it here ensures every container codec
+// only ever sees bytes after the envelope, however it chooses to read them.
+type readerOnly struct {
+	io.Reader
+}
+
+// readEnvelope validates formatMagic, reports which container codec
+// follows it, and returns the remaining container-encoded bytes.
+func readEnvelope(r io.Reader) (Container, io.Reader, error) {
+	prefix := make([]byte, len(formatMagic)+1)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, nil, fmt.Errorf("rsimg: reading envelope: %w", err)
+	}
+	if !bytes.Equal(prefix[:len(formatMagic)], formatMagic[:]) {
+		return 0, nil, errors.New("rsimg: bad magic, not an rsimg file")
+	}
+	return Container(prefix[len(formatMagic)]), readerOnly{r}, nil
+}
+
+// Decode implements the signature image.RegisterFormat requires: it reads
+// the rsimg envelope, decodes the underlying container, and returns the
+// RS-coded pixel grid exactly as Encoder.Encode produced it. Callers still
+// need Decoder.Decode to reconstruct the original payload bytes from that
+// grid - Decode alone only gets you the picture, the same as any other
+// image codec's Decode.
+func Decode(r io.Reader) (image.Image, error) {
+	container, rest, err := readEnvelope(r)
+	if err != nil {
+		return nil, err
+	}
+	switch container {
+	case ContainerPNG:
+		return png.Decode(rest)
+	case ContainerBMP:
+		return bmp.Decode(rest)
+	case ContainerTIFF:
+		return tiff.Decode(rest)
+	default:
+		return nil, fmt.Errorf("rsimg: unknown container %d", container)
+	}
+}
+
+// DecodeConfig implements the signature image.RegisterFormat requires,
+// returning the pixel grid's dimensions and color model without
+// reconstructing the payload.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	container, rest, err := readEnvelope(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	switch container {
+	case ContainerPNG:
+		return png.DecodeConfig(rest)
+	case ContainerBMP:
+		return bmp.DecodeConfig(rest)
+	case ContainerTIFF:
+		return tiff.DecodeConfig(rest)
+	default:
+		return image.Config{}, fmt.Errorf("rsimg: unknown container %d", container)
+	}
+}
+
+// Header is the layout information Encoder stores in the pixel stream,
+// exposed for callers that want it without going through Decoder.Decode.
+type Header struct {
+	DataShards   int
+	ParityShards int
+	Interleave   bool
+	PixelFormat  PixelFormat
+	CodecID      byte
+	OrigLen      uint32
+	ShardSize    uint32
+	NumStripes   uint32
+}
+
+// Inspect reports an rsimg file's shard layout and original payload
+// length. It currently decodes the whole container to get at the pixel
+// header; a true zero-copy peek becomes possible once StreamDecoder can
+// pull just the first rows instead of the whole image.
+func Inspect(r io.Reader) (Header, error) {
+	img, err := Decode(r)
+	if err != nil {
+		return Header{}, err
+	}
+	h, err := parseHeader(extractHeaderBytes(img, headerSize))
+	if err != nil {
+		return Header{}, err
+	}
+	return Header{
+		DataShards:   h.dataShards,
+		ParityShards: h.parityShards,
+		Interleave:   h.interleave,
+		PixelFormat:  h.pixelFormat,
+		CodecID:      h.codecID,
+		OrigLen:      h.origLen,
+		ShardSize:    h.shardSize,
+		NumStripes:   h.numStripes,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package rsimg
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestWriteFileRoundTripsThroughRegisteredFormat(t *testing.T) {
+	containers := []Container{ContainerPNG, ContainerBMP, ContainerTIFF}
+
+	for _, container := range containers {
+		enc, err := NewEncoder(DefaultEncoderOptions())
+		if err != nil {
+			t.Fatalf("container %d: NewEncoder: %v", container, err)
+		}
+
+		payload := make([]byte, 300)
+		rand.New(rand.NewSource(int64(container) + 1)).Read(payload)
+
+		img, err := enc.Encode(payload)
+		if err != nil {
+			t.Fatalf("container %d: Encode: %v", container, err)
+		}
+
+		var buf bytes.Buffer
+		if err := WriteFile(&buf, img, container); err != nil {
+			t.Fatalf("container %d: WriteFile: %v", container, err)
+		}
+
+		// image.Decode must recognize the file purely from its bytes,
+		// with no hint that it's an rsimg file.
+		decodedImg, format, err := image.Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("container %d: image.Decode: %v", container, err)
+		}
+		if format != "rsimg" {
+			t.Fatalf("container %d: image.Decode picked format %q, want rsimg", container, format)
+		}
+
+		got, err := NewDecoder().Decode(decodedImg)
+		if err != nil {
+			t.Fatalf("container %d: Decode: %v", container, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("container %d: decoded data does not match original", container)
+		}
+
+		hdr, err := Inspect(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("container %d: Inspect: %v", container, err)
+		}
+		if hdr.OrigLen != uint32(len(payload)) {
+			t.Fatalf("container %d: Inspect OrigLen = %d, want %d", container, hdr.OrigLen, len(payload))
+		}
+	}
+}
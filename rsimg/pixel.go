@@ -0,0 +1,124 @@
+package rsimg
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// PixelFormat selects how many payload bytes are packed into each image
+// pixel. Gray8 matches the original implementation; RGB24 and RGBA32 use
+// the channels a standard RGB(A) PNG already has to triple or quadruple
+// capacity per pixel.
+type PixelFormat byte
+
+const (
+	PixelGray8 PixelFormat = iota
+	PixelRGB24
+	PixelRGBA32
+)
+
+// bytesPerPixel returns how many payload bytes a single pixel in this
+// format carries.
+func (f PixelFormat) bytesPerPixel() int {
+	switch f {
+	case PixelRGB24:
+		return 3
+	case PixelRGBA32:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// pixelizeWithHeader lays header and body out as a single square image.
+// The header always gets one byte per pixel, regardless of format, because
+// the format itself is one of the fields recorded in the header: Decoder
+// has to be able to read it back before it knows how densely the body is
+// packed. The body is packed bytesPerPixel(format) bytes per pixel.
+//
+// The canvas is backed by image.NRGBA rather than image.RGBA: payload bytes
+// are arbitrary, so they routinely violate the alpha-premultiplied
+// invariant (R, G, B <= A) that image.RGBA and Go's PNG encoder assume.
+// NRGBA stores channels un-premultiplied, so payload bytes survive a PNG
+// round trip exactly regardless of what the alpha byte happens to be.
+func pixelizeWithHeader(header, body []byte, format PixelFormat) image.Image {
+	bpp := format.bytesPerPixel()
+	numBodyPixels := (len(body) + bpp - 1) / bpp
+	totalPixels := len(header) + numBodyPixels
+	sideLength := int(math.Ceil(math.Sqrt(float64(totalPixels))))
+	if sideLength == 0 {
+		sideLength = 1
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, sideLength, sideLength))
+	setPixel := func(i int, c color.NRGBA) {
+		img.SetNRGBA(i%sideLength, i/sideLength, c)
+	}
+
+	for i, b := range header {
+		setPixel(i, color.NRGBA{R: b, G: b, B: b, A: 255})
+	}
+	for i := 0; i < numBodyPixels; i++ {
+		setPixel(len(header)+i, pixelColor(body, i*bpp, format))
+	}
+	return img
+}
+
+// pixelColor builds the color for the pixel starting at payload[offset],
+// padding with zero bytes if payload is short.
+func pixelColor(payload []byte, offset int, format PixelFormat) color.NRGBA {
+	b := func(n int) byte {
+		if offset+n < len(payload) {
+			return payload[offset+n]
+		}
+		return 0
+	}
+	switch format {
+	case PixelRGB24:
+		return color.NRGBA{R: b(0), G: b(1), B: b(2), A: 255}
+	case PixelRGBA32:
+		return color.NRGBA{R: b(0), G: b(1), B: b(2), A: b(3)}
+	default:
+		return color.NRGBA{R: b(0), G: b(0), B: b(0), A: 255}
+	}
+}
+
+// extractHeaderBytes reads back the first n pixels of img as one header
+// byte per pixel, the inverse of the header loop in pixelizeWithHeader.
+func extractHeaderBytes(img image.Image, n int) []byte {
+	bounds := img.Bounds()
+	width := bounds.Max.X
+	extracted := make([]byte, n)
+	for i := 0; i < n; i++ {
+		x := i % width
+		y := i / width
+		gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		extracted[i] = gray.Y
+	}
+	return extracted
+}
+
+// extractBody reads n body bytes back out of img, starting right after the
+// headerPixels pixels the header occupies, in the given format.
+func extractBody(img image.Image, headerPixels int, format PixelFormat, n int) []byte {
+	bpp := format.bytesPerPixel()
+	bounds := img.Bounds()
+	width := bounds.Max.X
+	extracted := make([]byte, 0, n+bpp)
+	for i := 0; len(extracted) < n; i++ {
+		pixelIndex := headerPixels + i
+		x := pixelIndex % width
+		y := pixelIndex / width
+		nrgba := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		switch format {
+		case PixelRGB24:
+			extracted = append(extracted, nrgba.R, nrgba.G, nrgba.B)
+		case PixelRGBA32:
+			extracted = append(extracted, nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+		default:
+			extracted = append(extracted, nrgba.R)
+		}
+	}
+	return extracted[:n]
+}
@@ -0,0 +1,52 @@
+package rsimg
+
+import (
+	"bytes"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+func TestPixelFormatsRoundTripThroughPNG(t *testing.T) {
+	formats := []PixelFormat{PixelGray8, PixelRGB24, PixelRGBA32}
+
+	for _, format := range formats {
+		opts := DefaultEncoderOptions()
+		opts.PixelFormat = format
+		opts.MaxShardSize = 64
+
+		enc, err := NewEncoder(opts)
+		if err != nil {
+			t.Fatalf("format %d: NewEncoder: %v", format, err)
+		}
+
+		payload := make([]byte, 500)
+		rand.New(rand.NewSource(int64(format))).Read(payload)
+
+		img, err := enc.Encode(payload)
+		if err != nil {
+			t.Fatalf("format %d: Encode: %v", format, err)
+		}
+
+		// PNG is lossless, so round-tripping through it (at the highest
+		// compression level, which only changes the encoding, not the
+		// pixels) must not alter a single byte of the decoded payload.
+		var buf bytes.Buffer
+		pngEnc := &png.Encoder{CompressionLevel: png.BestCompression}
+		if err := pngEnc.Encode(&buf, img); err != nil {
+			t.Fatalf("format %d: png.Encode: %v", format, err)
+		}
+		decodedImg, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("format %d: png.Decode: %v", format, err)
+		}
+
+		got, err := NewDecoder().Decode(decodedImg)
+		if err != nil {
+			t.Fatalf("format %d: Decode: %v", format, err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("format %d: decoded data does not match original after PNG round trip", format)
+		}
+	}
+}
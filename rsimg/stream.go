@@ -0,0 +1,248 @@
+package rsimg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// StreamEncoder erasure-codes a payload one RS stripe at a time, so peak
+// memory stays proportional to a single stripe
+// (opts.DataShards*opts.MaxShardSize) instead of to the whole payload, the
+// same concern that drove the dicom codec's buffer-reuse work. Its output
+// is the same header-then-stripes byte layout Encoder.Encode renders as
+// pixels, just written out directly: a StreamEncoder's output is not
+// itself a decodable PNG/BMP/TIFF file. Read it back with
+// NewStreamDecoder, or buffer it fully and hand it to pixelizeWithHeader
+// if you need an actual picture.
+//
+// This is a deliberate scope cut, not an oversight: image/png's encoder
+// and decoder both operate on a whole image.Image in one Encode/Decode
+// call, with no public API for writing or reading individual rows
+// incrementally (the row-level filtering and zlib framing happen inside
+// unexported writer/reader state). Bounding memory for an actual
+// PNG/BMP/TIFF file would mean reimplementing that row/filter/zlib layer
+// ourselves instead of calling into the stdlib and x/image codecs the
+// rest of this package relies on. Until that's worth the maintenance
+// cost, StreamEncoder/StreamDecoder trade "produces a real image file"
+// for "bounded memory, own format" - large payloads that need an actual
+// picture still have to go through Encoder.Encode and pay the whole-image
+// memory cost.
+type StreamEncoder struct {
+	w    io.Writer
+	opts EncoderOptions
+	rs   reedsolomon.Encoder
+
+	stripeDataSize int
+	numStripes     uint32
+	totalSize      int64
+
+	written        int64
+	stripesFlushed uint32
+	buf            []byte // bytes accumulated for the in-progress stripe
+}
+
+// NewStreamEncoder validates opts, writes the header for a payload of
+// totalSize bytes, and returns a StreamEncoder ready for Write. totalSize
+// must be known up front because the header - like Encoder's - records
+// the original length and stripe count so Decoder needs no out-of-band
+// knowledge; that is the one place this streaming API can't avoid
+// buffering-free operation.
+func NewStreamEncoder(w io.Writer, opts EncoderOptions, totalSize int64) (*StreamEncoder, error) {
+	if opts.DataShards <= 0 || opts.ParityShards < 0 {
+		return nil, errors.New("rsimg: DataShards must be positive and ParityShards non-negative")
+	}
+	if opts.MaxShardSize <= 0 {
+		return nil, errors.New("rsimg: MaxShardSize must be positive")
+	}
+	if totalSize < 0 {
+		return nil, errors.New("rsimg: totalSize must be non-negative")
+	}
+	rs, err := reedsolomon.New(opts.DataShards, opts.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+
+	stripeDataSize := opts.DataShards * opts.MaxShardSize
+	numStripes := uint32(1)
+	if totalSize > 0 {
+		numStripes = uint32((totalSize + int64(stripeDataSize) - 1) / int64(stripeDataSize))
+	}
+
+	hdr := make([]byte, headerSize)
+	putHeader(hdr, opts, CodecRaw, uint32(totalSize), numStripes)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+
+	return &StreamEncoder{
+		w:              w,
+		opts:           opts,
+		rs:             rs,
+		stripeDataSize: stripeDataSize,
+		numStripes:     numStripes,
+		totalSize:      totalSize,
+		buf:            make([]byte, 0, stripeDataSize),
+	}, nil
+}
+
+// Write buffers p, encoding and emitting one stripe at a time as the
+// buffer fills, so it never holds more than one stripe's worth of
+// plaintext in memory.
+func (e *StreamEncoder) Write(p []byte) (int, error) {
+	if e.written+int64(len(p)) > e.totalSize {
+		return 0, errors.New("rsimg: wrote more than totalSize bytes")
+	}
+	e.written += int64(len(p))
+	n := len(p)
+
+	for len(p) > 0 {
+		take := min(e.stripeDataSize-len(e.buf), len(p))
+		e.buf = append(e.buf, p[:take]...)
+		p = p[take:]
+		if len(e.buf) == e.stripeDataSize {
+			if err := e.flushStripe(e.buf); err != nil {
+				return 0, err
+			}
+			e.buf = e.buf[:0]
+		}
+	}
+	return n, nil
+}
+
+// Close flushes whatever stripes remain to satisfy the stripe count
+// promised by the header - ordinarily one final, zero-padded short
+// stripe - and must be called exactly once after the last Write.
+func (e *StreamEncoder) Close() error {
+	for e.stripesFlushed < e.numStripes {
+		chunk := make([]byte, e.stripeDataSize)
+		copy(chunk, e.buf)
+		if err := e.flushStripe(chunk); err != nil {
+			return err
+		}
+		if len(e.buf) > e.stripeDataSize {
+			e.buf = e.buf[e.stripeDataSize:]
+		} else {
+			e.buf = nil
+		}
+	}
+	return nil
+}
+
+func (e *StreamEncoder) flushStripe(chunk []byte) error {
+	totalShards := e.opts.DataShards + e.opts.ParityShards
+	shardBytes := e.opts.MaxShardSize
+
+	shards := make([][]byte, totalShards)
+	for s := 0; s < e.opts.DataShards; s++ {
+		shards[s] = chunk[s*shardBytes : (s+1)*shardBytes]
+	}
+	for s := e.opts.DataShards; s < totalShards; s++ {
+		shards[s] = make([]byte, shardBytes)
+	}
+	if err := e.rs.Encode(shards); err != nil {
+		return fmt.Errorf("rsimg: encoding stripe %d: %w", e.stripesFlushed, err)
+	}
+	if _, err := e.w.Write(shardCRCs(shards)); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(layoutStripe(shards, e.opts.ShardInterleave)); err != nil {
+		return err
+	}
+	e.stripesFlushed++
+	return nil
+}
+
+// StreamDecoder reverses StreamEncoder, reconstructing and yielding
+// plaintext one stripe at a time via Read so peak memory again stays
+// proportional to a single stripe rather than the whole payload.
+type StreamDecoder struct {
+	r  io.Reader
+	h  header
+	rs reedsolomon.Encoder
+
+	totalShards int
+	crcLen      int
+	stripeBytes int
+	stripesRead uint32
+	remaining   uint32 // origLen bytes not yet returned by Read
+	pending     []byte // reconstructed bytes not yet returned by Read
+}
+
+// NewStreamDecoder reads and validates the header from r and returns a
+// StreamDecoder ready for Read.
+func NewStreamDecoder(r io.Reader) (*StreamDecoder, error) {
+	hdrBuf := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, hdrBuf); err != nil {
+		return nil, fmt.Errorf("rsimg: reading header: %w", err)
+	}
+	h, err := parseHeader(hdrBuf)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := reedsolomon.New(h.dataShards, h.parityShards)
+	if err != nil {
+		return nil, err
+	}
+
+	totalShards := h.dataShards + h.parityShards
+	crcLen := crcBlockSize(totalShards)
+	return &StreamDecoder{
+		r:           r,
+		h:           h,
+		rs:          rs,
+		totalShards: totalShards,
+		crcLen:      crcLen,
+		stripeBytes: crcLen + totalShards*int(h.shardSize),
+		remaining:   h.origLen,
+	}, nil
+}
+
+// Read implements io.Reader, pulling and reconstructing one stripe at a
+// time from the underlying reader as needed.
+func (d *StreamDecoder) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.remaining == 0 {
+			return 0, io.EOF
+		}
+		if d.stripesRead >= d.h.numStripes {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		// d.stripeBytes comes straight from the untrusted stream header,
+		// so a forged header can claim an enormous stripe. Reading
+		// through a LimitReader with io.ReadAll, rather than
+		// make([]byte, d.stripeBytes) up front, means allocation stays
+		// proportional to how many bytes r actually has to offer: a
+		// short, forged stream hits EOF - and returns an error - long
+		// before a multi-gigabyte buffer is ever allocated.
+		buf, err := io.ReadAll(io.LimitReader(d.r, int64(d.stripeBytes)))
+		if err != nil {
+			return 0, fmt.Errorf("rsimg: reading stripe %d: %w", d.stripesRead, err)
+		}
+		if len(buf) != d.stripeBytes {
+			return 0, fmt.Errorf("rsimg: reading stripe %d: %w", d.stripesRead, io.ErrUnexpectedEOF)
+		}
+		crcBlock := buf[:d.crcLen]
+		shards := deinterleaveStripe(buf[d.crcLen:], d.totalShards, d.h.interleave)
+		verifyShardCRCs(shards, crcBlock)
+		if err := d.rs.Reconstruct(shards); err != nil {
+			return 0, fmt.Errorf("rsimg: reconstructing stripe %d: %w", d.stripesRead, err)
+		}
+		d.stripesRead++
+
+		for s := 0; s < d.h.dataShards; s++ {
+			d.pending = append(d.pending, shards[s]...)
+		}
+		if uint32(len(d.pending)) > d.remaining {
+			d.pending = d.pending[:d.remaining]
+		}
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	d.remaining -= uint32(n)
+	return n, nil
+}
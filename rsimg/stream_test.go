@@ -0,0 +1,135 @@
+package rsimg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestStreamEncodeDecodeRoundTrip(t *testing.T) {
+	opts := EncoderOptions{DataShards: 4, ParityShards: 2, MaxShardSize: 1024}
+	payload := make([]byte, 10*1024+17) // several stripes plus a short final one
+	rand.New(rand.NewSource(3)).Read(payload)
+
+	var buf bytes.Buffer
+	enc, err := NewStreamEncoder(&buf, opts, int64(len(payload)))
+	if err != nil {
+		t.Fatalf("NewStreamEncoder: %v", err)
+	}
+	// Write in oddly-sized chunks to exercise buffering across calls.
+	for i := 0; i < len(payload); {
+		n := 777
+		if i+n > len(payload) {
+			n = len(payload) - i
+		}
+		if _, err := enc.Write(payload[i : i+n]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		i += n
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	dec, err := NewStreamDecoder(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("NewStreamDecoder: %v", err)
+	}
+	got, err := io.ReadAll(dec)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("streamed round trip produced %d bytes, want %d matching original", len(got), len(payload))
+	}
+}
+
+// TestStreamDecoderRejectsForgedHeaderClaimingMoreDataThanStreamHolds
+// guards against a forged shardSize driving Read into allocating a
+// stripe-sized buffer before any real shard bytes have been read: a short
+// stream with a header claiming a huge shard size must fail fast with an
+// error, not attempt a multi-gigabyte allocation.
+func TestStreamDecoderRejectsForgedHeaderClaimingMoreDataThanStreamHolds(t *testing.T) {
+	opts := EncoderOptions{DataShards: 1, ParityShards: 0, MaxShardSize: 1}
+	hdr := make([]byte, headerSize)
+	putHeader(hdr, opts, CodecRaw, 1, 1)
+	binary.BigEndian.PutUint32(hdr[15:19], 0xFFFFFFFF) // forge shardSize
+
+	r := io.MultiReader(bytes.NewReader(hdr), bytes.NewReader([]byte{0, 1, 2}))
+	dec, err := NewStreamDecoder(r)
+	if err != nil {
+		t.Fatalf("NewStreamDecoder: %v", err)
+	}
+	if _, err := io.ReadAll(dec); err == nil {
+		t.Fatalf("expected forged shardSize to be rejected, got nil error")
+	}
+}
+
+// BenchmarkStreamEncodeDecode processes payloads of increasing size through
+// the streaming API. Encoder and decoder are connected by an io.Pipe
+// instead of a buffered intermediary: a pipe has no internal buffer, so
+// encoded bytes only ever exist as the one in-flight stripe Write is
+// currently blocked handing to Read, on either side. That's what actually
+// exercises the bounded-memory claim - buffering the encoded stream into a
+// bytes.Buffer first would hold the whole encoded payload in memory and
+// defeat the point. Because StreamEncoder and StreamDecoder only ever hold
+// one stripe's worth of bytes at a time by construction, bytes/op here
+// grows with the number of stripes, not with payload size outpacing it.
+func BenchmarkStreamEncodeDecode(b *testing.B) {
+	sizes := []int{1 << 20, 10 << 20, 100 << 20}
+	opts := EncoderOptions{DataShards: 4, ParityShards: 2, MaxShardSize: 1 << 16}
+
+	for _, size := range sizes {
+		payload := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(payload)
+
+		b.Run(formatSize(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pr, pw := io.Pipe()
+				encErrCh := make(chan error, 1)
+				go func() {
+					enc, err := NewStreamEncoder(pw, opts, int64(size))
+					if err != nil {
+						pw.CloseWithError(err)
+						encErrCh <- err
+						return
+					}
+					if _, err := enc.Write(payload); err != nil {
+						pw.CloseWithError(err)
+						encErrCh <- err
+						return
+					}
+					if err := enc.Close(); err != nil {
+						pw.CloseWithError(err)
+						encErrCh <- err
+						return
+					}
+					encErrCh <- pw.Close()
+				}()
+
+				dec, err := NewStreamDecoder(pr)
+				if err != nil {
+					b.Fatalf("NewStreamDecoder: %v", err)
+				}
+				if _, err := io.Copy(io.Discard, dec); err != nil {
+					b.Fatalf("io.Copy: %v", err)
+				}
+				if err := <-encErrCh; err != nil {
+					b.Fatalf("encode: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func formatSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%dMB", n>>20)
+	}
+	return fmt.Sprintf("%dB", n)
+}